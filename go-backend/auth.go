@@ -0,0 +1,207 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// ==================== TRANSPORT & ADMIN AUTH ====================
+
+// AuthMode controls what, if anything, is required to reach the admin
+// surface beyond a valid TLS handshake.
+type AuthMode string
+
+const (
+	AuthModeNone          AuthMode = "none"
+	AuthModeAPIKey        AuthMode = "api-key"
+	AuthModeCert          AuthMode = "cert"
+	AuthModeCertAndAPIKey AuthMode = "cert+api-key"
+)
+
+// TLSConfig describes how the server should terminate TLS and gate the
+// admin surface. It's plain data so tests can construct one in memory
+// without touching the filesystem.
+type TLSConfig struct {
+	Enabled          bool     `yaml:"enabled"`
+	CertFile         string   `yaml:"certFile"`
+	KeyFile          string   `yaml:"keyFile"`
+	ClientCA         string   `yaml:"clientCA"`
+	Mode             AuthMode `yaml:"authMode"`
+	APIKeyHMACSecret string   `yaml:"apiKeyHMACSecret"`
+
+	// SessionIssuerHMACSecret gates POST /session/init. It is deliberately
+	// separate from APIKeyHMACSecret: the latter is handed to every
+	// browser widget to authorize low-trust telemetry submission, so it
+	// can't also be what authorizes minting a signing key for an
+	// arbitrary userId - anyone holding the widget's key could mint a
+	// session for any victim userId and sign fabricated telemetry as
+	// them. SessionIssuerHMACSecret is held only by the integrating
+	// site's own backend, which mints a session for a user it has
+	// already authenticated and relays the key to that user's browser.
+	SessionIssuerHMACSecret string `yaml:"sessionIssuerHMACSecret"`
+}
+
+// DefaultTLSConfig leaves TLS and admin auth off, matching today's
+// behavior, so existing local/dev setups keep working unconfigured.
+var DefaultTLSConfig = TLSConfig{
+	Enabled: false,
+	Mode:    AuthModeNone,
+}
+
+// LoadTLSConfig reads TLS/auth settings from a YAML file, falling back
+// to DefaultTLSConfig if the file is missing or malformed.
+func LoadTLSConfig(path string) TLSConfig {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Warn("no TLS config, TLS/admin auth disabled", "path", path)
+		return DefaultTLSConfig
+	}
+
+	cfg := DefaultTLSConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		logger.Warn("failed to parse TLS config, TLS/admin auth disabled", "path", path, "error", err)
+		return DefaultTLSConfig
+	}
+
+	return cfg
+}
+
+// GetAuthType returns the configured admin auth mode, defaulting to
+// AuthModeNone if unset.
+func (t TLSConfig) GetAuthType() AuthMode {
+	if t.Mode == "" {
+		return AuthModeNone
+	}
+	return t.Mode
+}
+
+// GetTLSConfig builds a *tls.Config for the server, requiring and
+// verifying client certificates against ClientCA whenever Mode calls for
+// a client cert.
+func (t TLSConfig) GetTLSConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load server cert/key: %w", err)
+	}
+
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	mode := t.GetAuthType()
+	if mode == AuthModeCert || mode == AuthModeCertAndAPIKey {
+		caData, err := os.ReadFile(t.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no valid certificates found in %s", t.ClientCA)
+		}
+
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsCfg, nil
+}
+
+// AdminAuthMiddleware gates routes under /api/v1/admin/*. A verified
+// client certificate (when Mode requires one) is already enforced by the
+// TLS handshake itself; this middleware additionally checks the signed
+// API key when Mode is api-key or cert+api-key.
+func AdminAuthMiddleware(cfg TLSConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mode := cfg.GetAuthType()
+
+		if mode == AuthModeAPIKey || mode == AuthModeCertAndAPIKey {
+			if _, ok := verifySignedAPIKey(c, cfg.APIKeyHMACSecret); !ok {
+				logger.Warn("rejected admin request: invalid API key", "ip", c.ClientIP())
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing API key"})
+				return
+			}
+		}
+
+		if mode == AuthModeCert || mode == AuthModeCertAndAPIKey {
+			if c.Request.TLS == nil || len(c.Request.TLS.PeerCertificates) == 0 {
+				logger.Warn("rejected admin request: no client certificate", "ip", c.ClientIP())
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "client certificate required"})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// APIKeyMiddleware protects non-admin endpoints from unauthenticated
+// telemetry spam. It's independent of the admin auth mode so /verify and
+// /challenge can require a key even when the admin surface uses mTLS.
+func APIKeyMiddleware(hmacSecret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if hmacSecret == "" {
+			c.Next()
+			return
+		}
+
+		keyID, ok := verifySignedAPIKey(c, hmacSecret)
+		if !ok {
+			logger.Warn("rejected request: invalid API key", "ip", c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing API key"})
+			return
+		}
+
+		// Exposed so handlers that mint per-user credentials (e.g.
+		// SessionInitHandler) can confirm the caller's key was actually
+		// issued for the userId it's requesting, not just that some valid
+		// key was presented.
+		c.Set("apiKeyID", keyID)
+		c.Next()
+	}
+}
+
+// verifySignedAPIKey checks the X-NeuroGate-Key header: hex(HMAC-SHA256(secret, keyID)).
+// The header format is "<keyID>.<signature>"; we recompute the signature
+// over keyID and compare in constant time. Returns the verified keyID
+// (e.g. a userID) alongside whether the signature checked out.
+func verifySignedAPIKey(c *gin.Context, secret string) (string, bool) {
+	header := c.GetHeader("X-NeuroGate-Key")
+	if header == "" || secret == "" {
+		return "", false
+	}
+
+	sep := -1
+	for i := len(header) - 1; i >= 0; i-- {
+		if header[i] == '.' {
+			sep = i
+			break
+		}
+	}
+	if sep <= 0 || sep == len(header)-1 {
+		return "", false
+	}
+
+	keyID := header[:sep]
+	signature := header[sep+1:]
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(keyID))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(expected)) != 1 {
+		return "", false
+	}
+	return keyID, true
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func signAPIKey(secret, keyID string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(keyID))
+	return keyID + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newTestContext(header string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	if header != "" {
+		req.Header.Set("X-NeuroGate-Key", header)
+	}
+	c.Request = req
+	return c
+}
+
+func TestVerifySignedAPIKeyAcceptsValidSignature(t *testing.T) {
+	secret := "test-secret"
+	c := newTestContext(signAPIKey(secret, "user-1"))
+
+	keyID, ok := verifySignedAPIKey(c, secret)
+	if !ok {
+		t.Fatal("expected a correctly signed key to verify")
+	}
+	if keyID != "user-1" {
+		t.Fatalf("expected keyID %q, got %q", "user-1", keyID)
+	}
+}
+
+func TestVerifySignedAPIKeyRejectsTamperedKeyID(t *testing.T) {
+	secret := "test-secret"
+	signed := signAPIKey(secret, "user-1")
+
+	// Swap in a different keyID without resigning, as an attacker who only
+	// knows a victim's userId (but not the secret) would have to.
+	c := newTestContext("user-2" + signed[len("user-1"):])
+
+	if _, ok := verifySignedAPIKey(c, secret); ok {
+		t.Fatal("expected a tampered keyID to fail verification")
+	}
+}
+
+func TestVerifySignedAPIKeyRejectsMissingHeader(t *testing.T) {
+	c := newTestContext("")
+	if _, ok := verifySignedAPIKey(c, "test-secret"); ok {
+		t.Fatal("expected a missing header to fail verification")
+	}
+}
+
+func TestVerifySignedAPIKeyRejectsEmptySecret(t *testing.T) {
+	c := newTestContext(signAPIKey("test-secret", "user-1"))
+	if _, ok := verifySignedAPIKey(c, ""); ok {
+		t.Fatal("expected an unconfigured secret to fail verification")
+	}
+}
+
+// TestTLSConfigIsPlainData exercises the claim in TLSConfig's doc comment:
+// it's constructible as plain data, with no filesystem access, which is what
+// lets AdminAuthMiddleware be unit tested without standing up real certs.
+func TestTLSConfigIsPlainData(t *testing.T) {
+	cfg := TLSConfig{
+		Mode:             AuthModeAPIKey,
+		APIKeyHMACSecret: "test-secret",
+	}
+
+	router := gin.New()
+	router.Use(AdminAuthMiddleware(cfg))
+	router.GET("/admin", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req.Header.Set("X-NeuroGate-Key", signAPIKey(cfg.APIKeyHMACSecret, "admin-1"))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a valid signed key to pass AdminAuthMiddleware, got %d", rec.Code)
+	}
+
+	badReq := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	badRec := httptest.NewRecorder()
+	router.ServeHTTP(badRec, badReq)
+
+	if badRec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a missing key to be rejected, got %d", badRec.Code)
+	}
+}
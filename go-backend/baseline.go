@@ -0,0 +1,318 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// ==================== BEHAVIORAL BASELINE ====================
+
+// WarmupSessions is the number of accepted sessions a user needs before
+// their personal baseline is trusted over the global defaults.
+const WarmupSessions = 5
+
+// WelfordStats tracks a running mean/variance for a single feature using
+// Welford's online algorithm, so updates are O(1) and numerically stable.
+type WelfordStats struct {
+	Count int64   `json:"count"`
+	Mean  float64 `json:"mean"`
+	M2    float64 `json:"m2"` // sum of squared deviations from the mean
+}
+
+// Update folds a new sample into the running mean/variance.
+func (w *WelfordStats) Update(value float64) {
+	w.Count++
+	delta := value - w.Mean
+	w.Mean += delta / float64(w.Count)
+	delta2 := value - w.Mean
+	w.M2 += delta * delta2
+}
+
+// StdDev returns the sample standard deviation, or 0 if too few samples.
+func (w *WelfordStats) StdDev() float64 {
+	if w.Count < 2 {
+		return 0
+	}
+	return math.Sqrt(w.M2 / float64(w.Count-1))
+}
+
+// ZScore returns how many standard deviations value is from the running
+// mean. Returns 0 when there isn't enough history to judge deviation.
+func (w *WelfordStats) ZScore(value float64) float64 {
+	stdDev := w.StdDev()
+	if w.Count < 2 || stdDev == 0 {
+		return 0
+	}
+	return (value - w.Mean) / stdDev
+}
+
+// UserBaseline is a per-user behavioral profile, updated after every
+// accepted challenge so the risk engine can score deviation against the
+// user's own history instead of fixed global thresholds.
+type UserBaseline struct {
+	UserID string `json:"userId"`
+	// SessionCount is every session accepted for this user, whether or not
+	// it carried telemetry - it's the figure admin tooling reports.
+	SessionCount int64 `json:"sessionCount"`
+	// TelemetryCount is how many of those sessions actually folded feature
+	// data into the WelfordStats below via Update. A challenge accepted
+	// through MarkSessionAccepted bumps SessionCount but not this, since it
+	// carries no telemetry to learn from.
+	TelemetryCount    int64        `json:"telemetryCount"`
+	FlightTime        WelfordStats `json:"flightTime"`
+	DwellTime         WelfordStats `json:"dwellTime"`
+	MouseAcceleration WelfordStats `json:"mouseAcceleration"`
+	EntropyScore      WelfordStats `json:"entropyScore"`
+	SessionDuration   WelfordStats `json:"sessionDuration"`
+}
+
+// IsWarm reports whether the baseline has seen enough sessions that
+// actually carried telemetry to be trusted over the global default
+// thresholds. Sessions accepted via OTP challenge alone don't count: they
+// bump SessionCount but never call Update, so a user who only ever warms
+// up through challenges would otherwise flip into z-score scoring with
+// every WelfordStats at Count=0 - a free pass, since ZScore treats "no
+// history" as "no deviation".
+func (b *UserBaseline) IsWarm() bool {
+	return b.TelemetryCount >= WarmupSessions
+}
+
+// RiskWeights controls how much each feature's z-score contributes to the
+// final trust score once a user's baseline is warm. Loaded from YAML so
+// tuning doesn't require a redeploy.
+type RiskWeights struct {
+	FlightTime        float64 `yaml:"flightTime"`
+	DwellTime         float64 `yaml:"dwellTime"`
+	MouseAcceleration float64 `yaml:"mouseAcceleration"`
+	EntropyScore      float64 `yaml:"entropyScore"`
+	SessionDuration   float64 `yaml:"sessionDuration"`
+}
+
+// DefaultRiskWeights mirrors the relative weight the legacy hard-coded
+// thresholds gave each feature.
+var DefaultRiskWeights = RiskWeights{
+	FlightTime:        7.0,
+	DwellTime:         4.0,
+	MouseAcceleration: 6.0,
+	EntropyScore:      9.0,
+	SessionDuration:   3.0,
+}
+
+// LoadRiskWeights reads feature weights from a YAML file, falling back to
+// DefaultRiskWeights if the file is missing or malformed.
+func LoadRiskWeights(path string) RiskWeights {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Warn("no risk weights file, using defaults", "path", path)
+		return DefaultRiskWeights
+	}
+
+	var weights RiskWeights
+	if err := yaml.Unmarshal(data, &weights); err != nil {
+		logger.Warn("failed to parse risk weights, using defaults", "path", path, "error", err)
+		return DefaultRiskWeights
+	}
+
+	return weights
+}
+
+// BaselineStore manages in-memory per-user behavioral baselines. It is
+// consulted and updated by the risk engine on every verify/challenge
+// round trip.
+type BaselineStore struct {
+	mu        sync.Mutex
+	baselines map[string]*UserBaseline
+	weights   RiskWeights
+	store     Store
+}
+
+// NewBaselineStore creates a baseline store with the given feature
+// weights, backed by store for cross-restart/cross-instance persistence.
+func NewBaselineStore(weights RiskWeights, store Store) *BaselineStore {
+	return &BaselineStore{
+		baselines: make(map[string]*UserBaseline),
+		weights:   weights,
+		store:     store,
+	}
+}
+
+// GetOrCreate returns the user's baseline, loading it from the store on
+// first sight of that user this process and creating an empty one if
+// nothing has been persisted yet.
+func (bs *BaselineStore) GetOrCreate(userID string) *UserBaseline {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	baseline, ok := bs.baselines[userID]
+	if ok {
+		return baseline
+	}
+
+	loaded, err := bs.store.LoadBaseline(userID)
+	if err != nil {
+		logger.Warn("failed to load baseline", "userId", userID, "error", err)
+	}
+	if loaded != nil {
+		bs.baselines[userID] = loaded
+		return loaded
+	}
+
+	baseline = &UserBaseline{UserID: userID}
+	bs.baselines[userID] = baseline
+	return baseline
+}
+
+// MarkSessionAccepted bumps a user's session count without touching the
+// feature distributions, for flows (like an OTP challenge) that confirm a
+// session was legitimate but don't carry full telemetry.
+func (bs *BaselineStore) MarkSessionAccepted(userID string) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	baseline, ok := bs.baselines[userID]
+	if !ok {
+		baseline = &UserBaseline{UserID: userID}
+		bs.baselines[userID] = baseline
+	}
+	baseline.SessionCount++
+	bs.persist(baseline)
+}
+
+// Reset clears a user's baseline, forcing them back into the warm-up
+// window, both in memory and in the store.
+func (bs *BaselineStore) Reset(userID string) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	delete(bs.baselines, userID)
+	if err := bs.store.SaveBaseline(&UserBaseline{UserID: userID}); err != nil {
+		logger.Warn("failed to reset stored baseline", "userId", userID, "error", err)
+	}
+}
+
+// Update folds the features observed in a telemetry sample into the
+// user's baseline and persists the result. Called once a session has been
+// accepted on the strength of its own telemetry (i.e. verified outright,
+// without a challenge).
+func (bs *BaselineStore) Update(userID string, telemetry TelemetryData) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	baseline, ok := bs.baselines[userID]
+	if !ok {
+		baseline = &UserBaseline{UserID: userID}
+		bs.baselines[userID] = baseline
+	}
+
+	baseline.SessionCount++
+	baseline.TelemetryCount++
+	if len(telemetry.KeystrokeDynamics.FlightTimes) > 2 {
+		baseline.FlightTime.Update(calculateVariance(telemetry.KeystrokeDynamics.FlightTimes))
+	}
+	if len(telemetry.KeystrokeDynamics.DwellTimes) > 2 {
+		baseline.DwellTime.Update(calculateVariance(telemetry.KeystrokeDynamics.DwellTimes))
+	}
+	if len(telemetry.MousePath) > 10 {
+		baseline.MouseAcceleration.Update(calculateMouseAcceleration(telemetry.MousePath))
+	}
+	baseline.EntropyScore.Update(telemetry.EntropyScore)
+	baseline.SessionDuration.Update(float64(telemetry.SessionDuration))
+	bs.persist(baseline)
+}
+
+// persist saves a baseline to the store, logging (not failing) on error
+// so a storage hiccup never blocks the request path. Callers must hold bs.mu.
+func (bs *BaselineStore) persist(baseline *UserBaseline) {
+	if err := bs.store.SaveBaseline(baseline); err != nil {
+		logger.Error("failed to persist baseline", "userId", baseline.UserID, "error", err)
+	}
+}
+
+var baselineStore *BaselineStore
+
+// CalculateRiskWithBaseline scores telemetry against a user's personal
+// baseline once it's warm, falling back to the legacy global thresholds
+// during the user's warm-up window. It returns the trust score.
+func CalculateRiskWithBaseline(telemetry TelemetryData, baseline *UserBaseline, weights RiskWeights) float64 {
+	if baseline == nil || !baseline.IsWarm() {
+		return CalculateRisk(telemetry)
+	}
+
+	// Start from the same ceiling CalculateRisk can reach for a fully
+	// natural session, not its neutral 50 starting point: a warm baseline
+	// replaces CalculateRisk's individual per-feature bonuses with "no
+	// deviation from your own history", so an at-mean, fully-warm session
+	// must be able to clear CHALLENGE_THRESHOLD just like a legacy session
+	// with ideal telemetry does. Starting at 50 and only ever subtracting
+	// left no returning user able to score above 50, challenging every
+	// warm user forever regardless of consistency.
+	trustScore := 100.0
+
+	// Every feature is penalized by how far it deviates from the user's
+	// own baseline in *either* direction - math.Abs throughout, not just
+	// on duration - since a session that's suddenly far more "natural"
+	// than that user's history (as well as far more robotic) is just as
+	// much of a deviation from their baseline as the reverse.
+	entropyZ := baseline.EntropyScore.ZScore(telemetry.EntropyScore)
+	trustScore -= math.Abs(entropyZ) * weights.EntropyScore
+
+	if len(telemetry.KeystrokeDynamics.FlightTimes) > 2 {
+		flightZ := baseline.FlightTime.ZScore(calculateVariance(telemetry.KeystrokeDynamics.FlightTimes))
+		trustScore -= math.Abs(flightZ) * weights.FlightTime
+	}
+
+	if len(telemetry.KeystrokeDynamics.DwellTimes) > 2 {
+		dwellZ := baseline.DwellTime.ZScore(calculateVariance(telemetry.KeystrokeDynamics.DwellTimes))
+		trustScore -= math.Abs(dwellZ) * weights.DwellTime
+	}
+
+	if len(telemetry.MousePath) > 10 {
+		accelZ := baseline.MouseAcceleration.ZScore(calculateMouseAcceleration(telemetry.MousePath))
+		trustScore -= math.Abs(accelZ) * weights.MouseAcceleration
+	}
+
+	durationZ := baseline.SessionDuration.ZScore(float64(telemetry.SessionDuration))
+	trustScore -= math.Abs(durationZ) * weights.SessionDuration
+
+	if trustScore > 100 {
+		trustScore = 100
+	} else if trustScore < 0 {
+		trustScore = 0
+	}
+
+	logger.Debug("baseline-adjusted trust score", "userId", baseline.UserID, "trustScore", trustScore, "sessions", baseline.SessionCount)
+
+	return trustScore
+}
+
+// ==================== ADMIN BASELINE HANDLERS ====================
+
+// GetBaselineHandler returns a user's current behavioral baseline.
+func GetBaselineHandler(c *gin.Context) {
+	userID := c.Param("userId")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userId is required"})
+		return
+	}
+
+	baseline := baselineStore.GetOrCreate(userID)
+	c.JSON(http.StatusOK, baseline)
+}
+
+// ResetBaselineHandler clears a user's baseline, sending them back
+// through the warm-up window.
+func ResetBaselineHandler(c *gin.Context) {
+	userID := c.Param("userId")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userId is required"})
+		return
+	}
+
+	baselineStore.Reset(userID)
+	logger.Info("baseline reset", "userId", userID)
+	c.JSON(http.StatusOK, gin.H{"status": "reset", "userId": userID})
+}
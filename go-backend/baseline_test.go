@@ -0,0 +1,107 @@
+package main
+
+import "testing"
+
+func TestWelfordStatsMeanAndStdDev(t *testing.T) {
+	var w WelfordStats
+	for _, v := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		w.Update(v)
+	}
+
+	if w.Mean != 5 {
+		t.Fatalf("expected mean 5, got %v", w.Mean)
+	}
+	if got := w.StdDev(); got < 2.1 || got > 2.2 {
+		t.Fatalf("expected stddev ~2.14, got %v", got)
+	}
+}
+
+func TestWelfordStatsZScoreNeedsHistory(t *testing.T) {
+	var w WelfordStats
+	w.Update(10)
+	if got := w.ZScore(100); got != 0 {
+		t.Fatalf("expected 0 z-score with a single sample, got %v", got)
+	}
+}
+
+// TestIsWarmRequiresTelemetryNotJustSessionCount guards against a user who
+// warms up purely by passing OTP challenges (which bump SessionCount via
+// MarkSessionAccepted but carry no telemetry) flipping into the z-score
+// path with every WelfordStats at Count=0 - where ZScore's "not enough
+// history" case would score every feature as a perfect match.
+func TestIsWarmRequiresTelemetryNotJustSessionCount(t *testing.T) {
+	challengeOnly := &UserBaseline{UserID: "u1", SessionCount: WarmupSessions}
+	if challengeOnly.IsWarm() {
+		t.Fatalf("expected a baseline warmed only via challenge acceptance to stay cold")
+	}
+
+	withTelemetry := &UserBaseline{UserID: "u1", SessionCount: WarmupSessions, TelemetryCount: WarmupSessions}
+	if !withTelemetry.IsWarm() {
+		t.Fatalf("expected a baseline with enough telemetry-backed sessions to be warm")
+	}
+}
+
+// TestCalculateRiskWithBaselinePenalizesBothDirections guards against the
+// sign-inversion bug where only durationZ was wrapped in math.Abs(): a
+// session that looks *more* natural than the user's history (negative
+// z-score) must be penalized the same as one that looks more bot-like
+// (positive z-score) of the same magnitude, not rewarded.
+func TestCalculateRiskWithBaselinePenalizesBothDirections(t *testing.T) {
+	baseline := &UserBaseline{UserID: "u1", SessionCount: WarmupSessions, TelemetryCount: WarmupSessions}
+	trainingEntropy := []float64{45, 55, 48, 52, 50, 46, 54, 49, 51, 50}
+	for _, v := range trainingEntropy {
+		baseline.EntropyScore.Update(v)
+		baseline.SessionDuration.Update(300)
+	}
+
+	weights := DefaultRiskWeights
+
+	above := TelemetryData{EntropyScore: 90, SessionDuration: 300}
+	below := TelemetryData{EntropyScore: 10, SessionDuration: 300}
+	atMean := TelemetryData{EntropyScore: 50, SessionDuration: 300}
+
+	scoreAbove := CalculateRiskWithBaseline(above, baseline, weights)
+	scoreBelow := CalculateRiskWithBaseline(below, baseline, weights)
+	scoreAtMean := CalculateRiskWithBaseline(atMean, baseline, weights)
+
+	if scoreAbove >= scoreAtMean {
+		t.Fatalf("deviation above the mean should lower trust score: above=%v atMean=%v", scoreAbove, scoreAtMean)
+	}
+	if scoreBelow >= scoreAtMean {
+		t.Fatalf("deviation below the mean should lower trust score: below=%v atMean=%v", scoreBelow, scoreAtMean)
+	}
+}
+
+// TestCalculateRiskWithBaselineClearsThresholdAtMean guards against the
+// scoring-ceiling bug where the warm path started from 50 and only ever
+// subtracted: a perfectly in-distribution, fully-warm session must clear
+// main.go's CHALLENGE_THRESHOLD (70), not top out at 50 forever.
+func TestCalculateRiskWithBaselineClearsThresholdAtMean(t *testing.T) {
+	const challengeThreshold = 70.0
+
+	baseline := &UserBaseline{UserID: "u1", SessionCount: WarmupSessions, TelemetryCount: WarmupSessions}
+	trainingEntropy := []float64{45, 55, 48, 52, 50, 46, 54, 49, 51, 50}
+	for _, v := range trainingEntropy {
+		baseline.EntropyScore.Update(v)
+		baseline.SessionDuration.Update(300)
+	}
+
+	atMean := TelemetryData{EntropyScore: 50, SessionDuration: 300}
+	score := CalculateRiskWithBaseline(atMean, baseline, DefaultRiskWeights)
+	if score < challengeThreshold {
+		t.Fatalf("expected an at-mean, fully-warm session to clear the challenge threshold: score=%v threshold=%v", score, challengeThreshold)
+	}
+}
+
+func TestCalculateRiskWithBaselineFallsBackBeforeWarm(t *testing.T) {
+	telemetry := TelemetryData{EntropyScore: 50, SessionDuration: 300}
+
+	cold := &UserBaseline{UserID: "u1", SessionCount: WarmupSessions - 1}
+	if got, want := CalculateRiskWithBaseline(telemetry, cold, DefaultRiskWeights), CalculateRisk(telemetry); got != want {
+		t.Fatalf("expected cold baseline to fall back to CalculateRisk: got %v want %v", got, want)
+	}
+
+	if got, want := CalculateRiskWithBaseline(telemetry, nil, DefaultRiskWeights), CalculateRisk(telemetry); got != want {
+		t.Fatalf("expected nil baseline to fall back to CalculateRisk: got %v want %v", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== CHALLENGE ISSUANCE & VERIFICATION ====================
+
+// challengeTTL is how long an issued code remains valid.
+const challengeTTL = 5 * time.Minute
+
+// IssueChallengeRequest asks the server to generate and bind a fresh OTP.
+type IssueChallengeRequest struct {
+	UserID    string `json:"userId" binding:"required"`
+	RequestID string `json:"requestId" binding:"required"`
+}
+
+// IssueChallengeResponse confirms a challenge was issued. The code itself
+// is never returned here in production - delivery is out of band (email/
+// SMS/authenticator app) - but is echoed back in DevCode during local
+// development so the flow can be exercised end to end without a mailer.
+type IssueChallengeResponse struct {
+	Status    string `json:"status"`
+	ExpiresAt int64  `json:"expiresAt"`
+	DevCode   string `json:"devCode,omitempty"`
+}
+
+// IssueChallengeHandler generates a 6-digit code bound to (userID,
+// requestID, expiresAt) and persists it through the Store, replacing the
+// old flow where the client simply told the server whether it passed.
+func IssueChallengeHandler(c *gin.Context) {
+	var req IssueChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	code, err := generateOTPCode()
+	if err != nil {
+		logger.Error("failed to generate OTP", "userId", req.UserID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue challenge"})
+		return
+	}
+
+	challenge := &ChallengeState{
+		UserID:    req.UserID,
+		RequestID: req.RequestID,
+		Code:      code,
+		ExpiresAt: time.Now().Add(challengeTTL).Unix(),
+	}
+
+	if err := store.SaveChallenge(challenge); err != nil {
+		logger.Error("failed to persist challenge", "userId", req.UserID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue challenge"})
+		return
+	}
+
+	// TODO: deliver `code` via email/SMS/authenticator instead of logging it.
+	logger.Debug("issued OTP", "userId", req.UserID, "requestId", req.RequestID, "expiresAt", challenge.ExpiresAt)
+	RecordChallengeOutcome("issued")
+
+	response := IssueChallengeResponse{
+		Status:    "issued",
+		ExpiresAt: challenge.ExpiresAt,
+	}
+	if gin.Mode() != gin.ReleaseMode {
+		response.DevCode = code
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// generateOTPCode returns a cryptographically random 6-digit numeric code.
+func generateOTPCode() (string, error) {
+	max := int64(1000000)
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	n := int64(b[0])<<24 | int64(b[1])<<16 | int64(b[2])<<8 | int64(b[3])
+	if n < 0 {
+		n = -n
+	}
+	return fmt.Sprintf("%06d", n%max), nil
+}
+
+// verifyCode compares two OTP codes in constant time.
+func verifyCode(submitted, stored string) bool {
+	if len(submitted) != len(stored) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(submitted), []byte(stored)) == 1
+}
@@ -0,0 +1,188 @@
+package main
+
+import "math"
+
+// ==================== SERVER-SIDE ENTROPY COMPUTATION ====================
+//
+// EntropyScore used to be whatever the client put in the request body,
+// which meant a bot could just POST entropyScore: 95 and sail through.
+// ComputeServerEntropyScore recomputes it from the raw MousePath instead,
+// combining three independent signals so there's no single feature a
+// replayed or scripted path can fake cheaply.
+
+const (
+	// entropyDirectionBins is the number of angular buckets used to
+	// quantise segment headings for the direction-entropy component.
+	entropyDirectionBins = 16
+
+	// jerkVarianceSaturation scales raw jerk variance (px/s^3 squared)
+	// into a bounded 0-100 score; chosen so typical human jitter lands
+	// in the 40-70 range rather than saturating immediately.
+	jerkVarianceSaturation = 5000.0
+
+	// entropyMismatchTolerance is how far a client-declared EntropyScore
+	// may diverge from the server-computed one before it's treated as
+	// spoofed.
+	entropyMismatchTolerance = 20.0
+
+	// entropyMismatchPenalty is subtracted from the trust score on top of
+	// discarding the client's value when a mismatch is detected.
+	entropyMismatchPenalty = 40.0
+)
+
+// pauseBinBoundsMs are the upper bounds (in ms) of the inter-point pause
+// buckets used for the pause-distribution entropy component; a final
+// bucket catches everything above the last bound.
+var pauseBinBoundsMs = []int64{50, 150, 400, 1000, 3000}
+
+// ComputeServerEntropyScore derives a 0-100 "naturalness" score for a
+// mouse path from three signals: the Shannon entropy of quantised
+// movement-direction bins, a saturating score from jerk (the third
+// derivative of position) variance, and the Shannon entropy of the
+// inter-point pause-duration distribution. Scripted/replayed paths tend
+// to be low on all three - perfectly steady headings, smooth
+// acceleration, and uniform timing - which a single faked number can't
+// reproduce across the board.
+func ComputeServerEntropyScore(path []MousePoint) float64 {
+	if len(path) < 3 {
+		return 0
+	}
+
+	score := 0.5*directionEntropyScore(path) + 0.3*jerkVarianceScore(path) + 0.2*pauseEntropyScore(path)
+
+	if score > 100 {
+		score = 100
+	} else if score < 0 {
+		score = 0
+	}
+	return score
+}
+
+// directionEntropyScore buckets each movement segment's heading into
+// entropyDirectionBins angular bins and returns the Shannon entropy of
+// that distribution as a percentage of its theoretical maximum. A bot
+// moving in straight lines or a fixed set of directions collapses onto a
+// handful of bins; natural movement spreads across most of them.
+func directionEntropyScore(path []MousePoint) float64 {
+	bins := make([]int, entropyDirectionBins)
+	total := 0
+
+	for i := 1; i < len(path); i++ {
+		dx := path[i].X - path[i-1].X
+		dy := path[i].Y - path[i-1].Y
+		if dx == 0 && dy == 0 {
+			continue
+		}
+
+		angle := math.Atan2(dy, dx)                    // -pi..pi
+		normalized := (angle + math.Pi) / (2 * math.Pi) // 0..1
+		bin := int(normalized * float64(entropyDirectionBins))
+		if bin >= entropyDirectionBins {
+			bin = entropyDirectionBins - 1
+		}
+		bins[bin]++
+		total++
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	maxEntropy := math.Log2(float64(entropyDirectionBins))
+	return shannonEntropy(bins, total) / maxEntropy * 100
+}
+
+// jerkVarianceScore computes the variance of jerk (the rate of change of
+// acceleration) along the path and maps it onto a bounded 0-100 scale.
+// Scripted movement is usually piecewise-linear or eased with a smooth
+// curve, so its jerk stays close to zero; real hands produce a much
+// noisier jerk signal.
+func jerkVarianceScore(path []MousePoint) float64 {
+	if len(path) < 4 {
+		return 0
+	}
+
+	var velX, velY []float64
+	for i := 1; i < len(path); i++ {
+		dt := float64(path[i].Time-path[i-1].Time) / 1000.0
+		if dt <= 0 {
+			continue
+		}
+		velX = append(velX, (path[i].X-path[i-1].X)/dt)
+		velY = append(velY, (path[i].Y-path[i-1].Y)/dt)
+	}
+	if len(velX) < 3 {
+		return 0
+	}
+
+	accel := make([]float64, 0, len(velX)-1)
+	for i := 1; i < len(velX); i++ {
+		ax := velX[i] - velX[i-1]
+		ay := velY[i] - velY[i-1]
+		accel = append(accel, math.Sqrt(ax*ax+ay*ay))
+	}
+	if len(accel) < 2 {
+		return 0
+	}
+
+	jerk := make([]float64, 0, len(accel)-1)
+	for i := 1; i < len(accel); i++ {
+		jerk = append(jerk, accel[i]-accel[i-1])
+	}
+	if len(jerk) == 0 {
+		return 0
+	}
+
+	variance := calculateVariance(jerk)
+	return 100 * variance / (variance + jerkVarianceSaturation)
+}
+
+// pauseEntropyScore buckets the gaps between consecutive mouse samples
+// into pauseBinBoundsMs ranges and returns the Shannon entropy of that
+// distribution as a percentage of its maximum. Bots sampled on a fixed
+// interval (or replaying a captured path) produce pauses that pile into
+// one or two buckets; human pointer movement has a much messier mix of
+// quick and hesitant segments.
+func pauseEntropyScore(path []MousePoint) float64 {
+	numBins := len(pauseBinBoundsMs) + 1
+	bins := make([]int, numBins)
+	total := 0
+
+	for i := 1; i < len(path); i++ {
+		dt := path[i].Time - path[i-1].Time
+		if dt < 0 {
+			continue
+		}
+
+		bin := numBins - 1
+		for idx, bound := range pauseBinBoundsMs {
+			if dt <= bound {
+				bin = idx
+				break
+			}
+		}
+		bins[bin]++
+		total++
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	maxEntropy := math.Log2(float64(numBins))
+	return shannonEntropy(bins, total) / maxEntropy * 100
+}
+
+// shannonEntropy returns the Shannon entropy, in bits, of the
+// distribution described by counts out of total observations.
+func shannonEntropy(counts []int, total int) float64 {
+	entropy := 0.0
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
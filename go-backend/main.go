@@ -5,6 +5,8 @@ import (
 	"log"
 	"math"
 	"net/http"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
@@ -40,14 +42,18 @@ type SecurityEvent struct {
 	Time       string  `json:"time"`   // Human-readable timestamp
 }
 
-// EventLog manages the in-memory security event log
+// EventLog keeps a small in-memory tail of recent security events for
+// fast reads, backed by the pluggable Store for everything durable or
+// older than the tail.
 type EventLog struct {
 	mu      sync.Mutex
 	events  []SecurityEvent
 	maxSize int
+	store   Store
 }
 
 var eventLog *EventLog
+var store Store
 
 // TelemetryData is the complete telemetry payload from the client
 type TelemetryData struct {
@@ -58,23 +64,33 @@ type TelemetryData struct {
 	Timestamp         int64             `json:"timestamp"`
 }
 
-// VerifyRequest represents the incoming verification request
+// VerifyRequest represents the incoming verification request. Nonce,
+// Timestamp and Signature are the anti-replay envelope: Signature must be
+// HMAC-SHA256(sessionKey, userID||nonce||telemetryHash||timestamp) using
+// the key handed out by POST /api/v1/session/init, Nonce must not have
+// been seen before, and Timestamp must be within requestTimestampSkew of
+// the server's clock. See SessionStore.ValidateRequest.
 type VerifyRequest struct {
 	UserID    string        `json:"userId" binding:"required"`
 	Telemetry TelemetryData `json:"telemetry" binding:"required"`
-	Timestamp int64         `json:"timestamp"`
+	Nonce     int64         `json:"nonce" binding:"required"`
+	Timestamp int64         `json:"timestamp" binding:"required"`
+	Signature string        `json:"signature" binding:"required"`
 }
 
 // RiskResponse is the response from the verify endpoint
 type RiskResponse struct {
-	TrustScore       float64 `json:"trustScore"`
-	RequiresChallenge bool   `json:"requiresChallenge"`
+	TrustScore        float64 `json:"trustScore"`
+	RequiresChallenge bool    `json:"requiresChallenge"`
 }
 
-// ChallengeRequest represents an OTP challenge submission
+// ChallengeRequest represents an OTP challenge submission. The server
+// validates Code against the value it issued for (UserID, RequestID) -
+// the client no longer gets to just declare success.
 type ChallengeRequest struct {
 	UserID    string `json:"userId" binding:"required"`
-	Success   bool   `json:"success"`
+	RequestID string `json:"requestId" binding:"required"`
+	Code      string `json:"code" binding:"required"`
 	Timestamp int64  `json:"timestamp"`
 }
 
@@ -101,8 +117,7 @@ type EventListResponse struct {
 // CalculateRisk analyzes telemetry data and returns a trust score (0-100)
 // Higher score = more trustworthy behavior
 func CalculateRisk(telemetry TelemetryData) float64 {
-	log.Printf("[Risk Engine] Analyzing user behavior...\n")
-	log.Printf("[Risk Engine] Entropy Score: %.2f\n", telemetry.EntropyScore)
+	logger.Debug("analyzing user behavior", "entropyScore", telemetry.EntropyScore)
 
 	trustScore := 50.0 // Start with baseline
 
@@ -110,13 +125,12 @@ func CalculateRisk(telemetry TelemetryData) float64 {
 	// Entropy score indicates movement naturalness (0-100)
 	// High entropy (>70) = natural, Low entropy (<40) = robotic
 	if telemetry.EntropyScore < 40 {
-		log.Printf("[Risk Engine] ⚠️ SUSPICIOUS: Low entropy score (robotic movement detected)\n")
+		logger.Debug("suspicious: low entropy score (robotic movement)", "entropyScore", telemetry.EntropyScore)
 		trustScore -= 35 // Significant penalty for linear/robotic movement
 	} else if telemetry.EntropyScore > 70 {
-		log.Printf("[Risk Engine] ✅ NATURAL: High entropy score (natural movement)\n")
+		logger.Debug("natural: high entropy score", "entropyScore", telemetry.EntropyScore)
 		trustScore += 25 // Bonus for natural movement
 	} else {
-		log.Printf("[Risk Engine] ℹ️ NEUTRAL: Moderate entropy score\n")
 		trustScore += 5 // Small bonus for moderate entropy
 	}
 
@@ -124,14 +138,15 @@ func CalculateRisk(telemetry TelemetryData) float64 {
 	// Analyze keystroke dynamics for bot-like patterns
 	if len(telemetry.KeystrokeDynamics.FlightTimes) > 2 {
 		flightVariance := calculateVariance(telemetry.KeystrokeDynamics.FlightTimes)
-		log.Printf("[Risk Engine] Flight time variance: %.2f\n", flightVariance)
+		keystrokeVarianceHistogram.Observe(flightVariance)
+		logger.Debug("flight time variance", "variance", flightVariance)
 
 		// Very low variance indicates perfectly timed (bot-like) typing
 		if flightVariance < 100 {
-			log.Printf("[Risk Engine] ⚠️ SUSPICIOUS: Perfectly uniform keystroke timing (possible bot)\n")
+			logger.Debug("suspicious: perfectly uniform keystroke timing (possible bot)")
 			trustScore -= 30
 		} else if flightVariance > 1000 {
-			log.Printf("[Risk Engine] ✅ NATURAL: High keystroke variance (human-like)\n")
+			logger.Debug("natural: high keystroke variance")
 			trustScore += 15
 		}
 	}
@@ -139,11 +154,11 @@ func CalculateRisk(telemetry TelemetryData) float64 {
 	// Analyze dwell times (how long keys are held)
 	if len(telemetry.KeystrokeDynamics.DwellTimes) > 2 {
 		dwellVariance := calculateVariance(telemetry.KeystrokeDynamics.DwellTimes)
-		log.Printf("[Risk Engine] Dwell time variance: %.2f\n", dwellVariance)
+		logger.Debug("dwell time variance", "variance", dwellVariance)
 
 		// Low variance on dwell times suggests consistent behavior
 		if dwellVariance < 50 {
-			log.Printf("[Risk Engine] ⚠️ SUSPICIOUS: Uniform dwell times (too consistent for human)\n")
+			logger.Debug("suspicious: uniform dwell times (too consistent for human)")
 			trustScore -= 15
 		}
 	}
@@ -151,10 +166,10 @@ func CalculateRisk(telemetry TelemetryData) float64 {
 	// ==================== SESSION DURATION ANALYSIS ====================
 	// Suspiciously quick or prolonged sessions might indicate automation
 	if telemetry.SessionDuration < 2000 { // Less than 2 seconds
-		log.Printf("[Risk Engine] ⚠️ SUSPICIOUS: Very quick session (%.0f ms)\n", float64(telemetry.SessionDuration))
+		logger.Debug("suspicious: very quick session", "durationMs", telemetry.SessionDuration)
 		trustScore -= 20
 	} else if telemetry.SessionDuration > 300000 { // More than 5 minutes
-		log.Printf("[Risk Engine] ⚠️ SUSPICIOUS: Very long session (%.0f ms)\n", float64(telemetry.SessionDuration))
+		logger.Debug("suspicious: very long session", "durationMs", telemetry.SessionDuration)
 		trustScore -= 10
 	}
 
@@ -162,14 +177,14 @@ func CalculateRisk(telemetry TelemetryData) float64 {
 	// Analyze mouse path for unnatural patterns
 	if len(telemetry.MousePath) > 10 {
 		acceleration := calculateMouseAcceleration(telemetry.MousePath)
-		log.Printf("[Risk Engine] Mouse acceleration variance: %.2f\n", acceleration)
+		logger.Debug("mouse acceleration variance", "variance", acceleration)
 
 		// Very low acceleration variance suggests linear movement (bot)
 		if acceleration < 1.0 {
-			log.Printf("[Risk Engine] ⚠️ SUSPICIOUS: Linear mouse movement detected\n")
+			logger.Debug("suspicious: linear mouse movement detected")
 			trustScore -= 25
 		} else if acceleration > 50 {
-			log.Printf("[Risk Engine] ✅ NATURAL: High acceleration variance in mouse movement\n")
+			logger.Debug("natural: high acceleration variance in mouse movement")
 			trustScore += 10
 		}
 	}
@@ -178,20 +193,17 @@ func CalculateRisk(telemetry TelemetryData) float64 {
 	// Too few keystrokes might indicate copy-paste or incomplete interaction
 	keystrokeCount := len(telemetry.KeystrokeDynamics.Keys)
 	if keystrokeCount < 3 {
-		log.Printf("[Risk Engine] ⚠️ SUSPICIOUS: Very few keystrokes (%d)\n", keystrokeCount)
+		logger.Debug("suspicious: very few keystrokes", "count", keystrokeCount)
 		trustScore -= 15
-	} else if keystrokeCount > 50 {
-		log.Printf("[Risk Engine] ℹ️ Many keystrokes (%d) - normal for longer input\n", keystrokeCount)
 	}
 
 	// ==================== MOUSE MOVEMENT COUNT ANALYSIS ====================
 	// No mouse movement might indicate keyboard-only bot
 	mousePointCount := len(telemetry.MousePath)
 	if mousePointCount < 5 {
-		log.Printf("[Risk Engine] ⚠️ SUSPICIOUS: Minimal mouse movement (%d points)\n", mousePointCount)
+		logger.Debug("suspicious: minimal mouse movement", "points", mousePointCount)
 		trustScore -= 10
 	} else if mousePointCount > 100 {
-		log.Printf("[Risk Engine] ✅ NATURAL: Extensive mouse movement (%d points)\n", mousePointCount)
 		trustScore += 5
 	}
 
@@ -203,8 +215,7 @@ func CalculateRisk(telemetry TelemetryData) float64 {
 		trustScore = 0
 	}
 
-	log.Printf("[Risk Engine] ✅ FINAL TRUST SCORE: %.2f/100\n", trustScore)
-	log.Printf("[Risk Engine] Challenge Required: %v (threshold: 70)\n", trustScore < 70)
+	logger.Debug("final trust score", "trustScore", trustScore, "requiresChallenge", trustScore < 70)
 
 	return trustScore
 }
@@ -277,7 +288,6 @@ func calculateMouseAcceleration(mousePath []MousePoint) float64 {
 
 // HealthHandler returns the health status of the API
 func HealthHandler(c *gin.Context) {
-	log.Printf("[API] GET /api/v1/health\n")
 	c.JSON(http.StatusOK, HealthResponse{
 		Status:  "online",
 		Message: "NeuroGate Backend is running",
@@ -286,24 +296,60 @@ func HealthHandler(c *gin.Context) {
 
 // VerifyBehaviorHandler processes telemetry and calculates risk
 func VerifyBehaviorHandler(c *gin.Context) {
-	log.Printf("[API] POST /api/v1/verify - Incoming request\n")
-
 	var req VerifyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("[API] ❌ Invalid request: %v\n", err)
+		logger.Warn("invalid verify request", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid request format",
 		})
 		return
 	}
 
-	log.Printf("[API] User: %s | Telemetry received\n", req.UserID)
-	log.Printf("[API] - Keystroke count: %d\n", len(req.Telemetry.KeystrokeDynamics.Keys))
-	log.Printf("[API] - Mouse points: %d\n", len(req.Telemetry.MousePath))
-	log.Printf("[API] - Session duration: %d ms\n", req.Telemetry.SessionDuration)
+	// Anti-replay: the request must carry a valid signature over its own
+	// content, tied to a session key the client can only have gotten from
+	// /session/init, with a nonce that hasn't been spent and a timestamp
+	// close to the server's clock.
+	telemetryHash, err := hashTelemetry(req.Telemetry)
+	if err != nil {
+		logger.Error("failed to hash telemetry", "userId", req.UserID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+	if err := sessionStore.ValidateRequest(req.UserID, req.Nonce, telemetryHash, req.Timestamp, req.Signature); err != nil {
+		logger.Warn("rejected verify request", "userId", req.UserID, "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or replayed request"})
+		return
+	}
+
+	logger.Debug("telemetry received", "userId", req.UserID,
+		"keystrokeCount", len(req.Telemetry.KeystrokeDynamics.Keys),
+		"mousePoints", len(req.Telemetry.MousePath),
+		"sessionDurationMs", req.Telemetry.SessionDuration)
+
+	// The client-declared EntropyScore is never trusted on its own: a bot
+	// can just POST a high score. Recompute it server-side from the raw
+	// mouse path and fall back to that value, heavily penalizing requests
+	// whose declared score disagrees with what the server measures.
+	serverEntropy := ComputeServerEntropyScore(req.Telemetry.MousePath)
+	clientEntropy := req.Telemetry.EntropyScore
+	entropyMismatch := math.Abs(serverEntropy-clientEntropy) > entropyMismatchTolerance
+	req.Telemetry.EntropyScore = serverEntropy
+	if entropyMismatch {
+		logger.Warn("entropy mismatch between client-declared and server-computed score",
+			"userId", req.UserID, "clientEntropy", clientEntropy, "serverEntropy", serverEntropy)
+		entropyMismatchTotal.Inc()
+	}
 
-	// Calculate risk using the Risk Engine
-	trustScore := CalculateRisk(req.Telemetry)
+	// Calculate risk using the Risk Engine, scored against the user's own
+	// behavioral baseline once it's out of its warm-up window.
+	baseline := baselineStore.GetOrCreate(req.UserID)
+	trustScore := CalculateRiskWithBaseline(req.Telemetry, baseline, baselineStore.weights)
+	if entropyMismatch {
+		trustScore -= entropyMismatchPenalty
+		if trustScore < 0 {
+			trustScore = 0
+		}
+	}
 
 	// Determine if challenge is required (trust score below threshold)
 	const CHALLENGE_THRESHOLD = 70.0
@@ -313,6 +359,8 @@ func VerifyBehaviorHandler(c *gin.Context) {
 	status := "success"
 	if requiresChallenge {
 		status = "challenged"
+	} else {
+		baselineStore.Update(req.UserID, req.Telemetry)
 	}
 	eventLog.LogEvent(SecurityEvent{
 		ID:         fmt.Sprintf("%s-%d", req.UserID, time.Now().UnixNano()),
@@ -324,61 +372,130 @@ func VerifyBehaviorHandler(c *gin.Context) {
 		Time:       time.Now().Format("2006-01-02 15:04:05"),
 	})
 
+	var flightVariance, dwellVariance, mouseAcceleration float64
+	if len(req.Telemetry.KeystrokeDynamics.FlightTimes) > 2 {
+		flightVariance = calculateVariance(req.Telemetry.KeystrokeDynamics.FlightTimes)
+	}
+	if len(req.Telemetry.KeystrokeDynamics.DwellTimes) > 2 {
+		dwellVariance = calculateVariance(req.Telemetry.KeystrokeDynamics.DwellTimes)
+	}
+	if len(req.Telemetry.MousePath) > 10 {
+		mouseAcceleration = calculateMouseAcceleration(req.Telemetry.MousePath)
+	}
+	RecordVerification(VerificationEvent{
+		UserID:            req.UserID,
+		Timestamp:         time.Now().Unix(),
+		TrustScore:        trustScore,
+		EntropyScore:      req.Telemetry.EntropyScore,
+		FlightVariance:    flightVariance,
+		DwellVariance:     dwellVariance,
+		MouseAcceleration: mouseAcceleration,
+		BaselineWarm:      baseline.IsWarm(),
+		RequiresChallenge: requiresChallenge,
+	})
+
 	response := RiskResponse{
-		TrustScore:       trustScore,
+		TrustScore:        trustScore,
 		RequiresChallenge: requiresChallenge,
 	}
 
-	log.Printf("[API] ✅ Response sent: TrustScore=%.2f, RequiresChallenge=%v\n", trustScore, requiresChallenge)
-
 	c.JSON(http.StatusOK, response)
 }
 
-// SubmitChallengeHandler processes OTP challenge results
+// SubmitChallengeHandler validates a submitted OTP against the value
+// issued by IssueChallengeHandler. Failed attempts are rate limited per
+// IP and per user so brute-forcing a code isn't free.
 func SubmitChallengeHandler(c *gin.Context) {
-	log.Printf("[API] POST /api/v1/challenge - Incoming request\n")
-
 	var req ChallengeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("[API] ❌ Invalid request: %v\n", err)
+		logger.Warn("invalid challenge request", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Invalid request format",
 		})
 		return
 	}
 
-	log.Printf("[API] User: %s | Challenge submission\n", req.UserID)
-	log.Printf("[API] Success: %v\n", req.Success)
-
-	// In a production system, you would:
-	// 1. Validate the OTP against a stored value
-	// 2. Update user's behavioral baseline if successful
-	// 3. Log failed attempts for security monitoring
-	// 4. Implement rate limiting
-
-	var response ChallengeResponse
+	ip := c.ClientIP()
+	logger.Debug("challenge submission", "userId", req.UserID, "requestId", req.RequestID)
+
+	if challengeRateLimiter.Blocked(ip) || challengeRateLimiter.Blocked(req.UserID) {
+		logger.Warn("blocked challenge attempt: too many failures", "userId", req.UserID, "ip", ip)
+		RecordChallengeOutcome("blocked")
+		eventLog.LogEvent(SecurityEvent{
+			ID:         fmt.Sprintf("%s-%d", req.UserID, time.Now().UnixNano()),
+			Timestamp:  time.Now().Unix(),
+			UserID:     req.UserID,
+			IP:         ip,
+			TrustScore: 0,
+			Status:     "blocked",
+			Time:       time.Now().Format("2006-01-02 15:04:05"),
+		})
+		c.JSON(http.StatusTooManyRequests, ChallengeResponse{
+			Status:  "blocked",
+			Message: "Too many failed attempts, try again later",
+		})
+		return
+	}
 
-	if req.Success {
-		log.Printf("[API] ✅ Challenge accepted for user: %s\n", req.UserID)
-		response = ChallengeResponse{
-			Status:  "accepted",
-			Message: "Challenge verified successfully",
-		}
-	} else {
-		log.Printf("[API] ❌ Challenge rejected for user: %s\n", req.UserID)
-		response = ChallengeResponse{
+	// ConsumeChallenge only marks the challenge consumed once req.Code has
+	// verified against it, so a mistyped code leaves it intact for a retry
+	// instead of permanently burning it.
+	_, err := store.ConsumeChallenge(req.UserID, req.RequestID, req.Code)
+	if err != nil {
+		logger.Info("challenge rejected", "userId", req.UserID, "error", err)
+		challengeRateLimiter.RecordFailure(ip)
+		challengeRateLimiter.RecordFailure(req.UserID)
+		RecordChallengeOutcome("rejected")
+		c.JSON(http.StatusOK, ChallengeResponse{
 			Status:  "rejected",
 			Message: "Challenge verification failed",
-		}
+		})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	logger.Info("challenge accepted", "userId", req.UserID)
+	challengeRateLimiter.Reset(ip)
+	challengeRateLimiter.Reset(req.UserID)
+	// A passed challenge counts as an accepted session for baseline purposes.
+	baselineStore.MarkSessionAccepted(req.UserID)
+	RecordChallengeOutcome("accepted")
+
+	c.JSON(http.StatusOK, ChallengeResponse{
+		Status:  "accepted",
+		Message: "Challenge verified successfully",
+	})
 }
 
-// GetEventsHandler returns the last 50 security events
+// GetEventsHandler returns security events, paginated and optionally
+// filtered by user and time range. Query params: userId, since (unix
+// seconds), limit (default 50, max 500).
 func GetEventsHandler(c *gin.Context) {
-	log.Printf("[API] GET /api/v1/admin/events\n")
-	events := eventLog.GetEvents()
+	userID := c.Query("userId")
+
+	var since int64
+	if v := c.Query("since"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			since = parsed
+		}
+	}
+
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	events, err := eventLog.store.ListEvents(userID, since, limit)
+	if err != nil {
+		logger.Error("failed to list events", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list events"})
+		return
+	}
+
 	c.JSON(http.StatusOK, EventListResponse{
 		Events: events,
 		Count:  len(events),
@@ -387,27 +504,33 @@ func GetEventsHandler(c *gin.Context) {
 
 // ==================== EVENT LOG FUNCTIONS ====================
 
-// NewEventLog creates a new event log
-func NewEventLog(maxSize int) *EventLog {
+// NewEventLog creates a new event log backed by store.
+func NewEventLog(maxSize int, store Store) *EventLog {
 	return &EventLog{
 		events:  make([]SecurityEvent, 0, maxSize),
 		maxSize: maxSize,
+		store:   store,
 	}
 }
 
-// LogEvent adds a security event to the log
+// LogEvent records a security event in the in-memory tail and persists
+// it through the Store.
 func (el *EventLog) LogEvent(event SecurityEvent) {
 	el.mu.Lock()
-	defer el.mu.Unlock()
-
 	el.events = append(el.events, event)
 
-	// Keep only the last maxSize events
+	// Keep only the last maxSize events in the in-memory tail
 	if len(el.events) > el.maxSize {
 		el.events = el.events[len(el.events)-el.maxSize:]
 	}
+	el.mu.Unlock()
+
+	if err := el.store.SaveEvent(event); err != nil {
+		logger.Error("failed to persist event", "userId", event.UserID, "error", err)
+	}
+	liveEvents.publish(event)
 
-	log.Printf("[Admin] Event logged: User=%s, TrustScore=%.2f, Status=%s\n", event.UserID, event.TrustScore, event.Status)
+	logger.Debug("event logged", "userId", event.UserID, "trustScore", event.TrustScore, "status", event.Status)
 }
 
 // GetEvents returns a copy of all events
@@ -424,14 +547,29 @@ func (el *EventLog) GetEvents() []SecurityEvent {
 }
 
 func main() {
-	log.Println("╔════════════════════════════════════════════════════╗")
-	log.Println("║         NeuroGate Backend - Risk Engine             ║")
-	log.Println("║         Behavioral Biometrics Authentication        ║")
-	log.Println("╚════════════════════════════════════════════════════╝")
+	debug := os.Getenv("NEUROGATE_DEBUG") == "true"
+	InitLogger(debug)
+	logger.Info("starting NeuroGate backend", "debug", debug)
+
+	// Initialize the persistent store (SQLite by default; see config/store.yaml)
+	storeCfg := LoadStoreConfig("config/store.yaml")
+	var err error
+	store, err = NewStore(storeCfg)
+	if err != nil {
+		log.Fatalf("[Boot] Failed to initialize store (%s): %v", storeCfg.Backend, err)
+	}
+	logger.Info("store initialized", "backend", storeCfg.Backend)
 
-	// Initialize event log (keep last 50 events)
-	eventLog = NewEventLog(50)
-	log.Println("[Boot] Event log initialized (max 50 events)")
+	// Initialize event log (in-memory tail of 50, durable via store)
+	eventLog = NewEventLog(50, store)
+
+	// Initialize behavioral baseline store
+	riskWeights := LoadRiskWeights("config/risk_weights.yaml")
+	baselineStore = NewBaselineStore(riskWeights, store)
+
+	// Load TLS/auth config (disabled unless config/tls.yaml says otherwise)
+	tlsConfig := LoadTLSConfig("config/tls.yaml")
+	logger.Info("admin auth mode configured", "mode", tlsConfig.GetAuthType())
 
 	// Initialize Gin router
 	router := gin.Default()
@@ -452,30 +590,51 @@ func main() {
 
 	router.Use(cors.New(config))
 
-	// Add request logging middleware
+	// Add request logging/recovery/metrics middleware
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
-
-	log.Println("[Boot] CORS enabled for localhost:5173")
-	log.Println("[Boot] Initializing API routes...")
+	router.Use(RequestDurationMiddleware())
 
 	// ==================== ROUTES ====================
 
 	// Health check endpoint
 	router.GET("/api/v1/health", HealthHandler)
-	log.Println("[Boot] ✅ GET /api/v1/health")
 
-	// Verify behavior endpoint
-	router.POST("/api/v1/verify", VerifyBehaviorHandler)
-	log.Println("[Boot] ✅ POST /api/v1/verify")
+	// Session issuance is gated separately from the rest of telemetry: it
+	// mints a signing key for whatever userId it's handed, so it's
+	// authorized with SessionIssuerHMACSecret (held only by the
+	// integrating site's backend) rather than the widget-facing
+	// APIKeyHMACSecret every browser client carries.
+	sessionIssuer := router.Group("/api/v1")
+	sessionIssuer.Use(APIKeyMiddleware(tlsConfig.SessionIssuerHMACSecret))
+	{
+		sessionIssuer.POST("/session/init", SessionInitHandler)
+	}
+
+	// Telemetry endpoints require a signed API key when one is configured,
+	// to keep unauthenticated clients from spamming /verify and /challenge.
+	telemetry := router.Group("/api/v1")
+	telemetry.Use(APIKeyMiddleware(tlsConfig.APIKeyHMACSecret))
+	{
+		telemetry.POST("/verify", VerifyBehaviorHandler)
+		telemetry.POST("/challenge/issue", IssueChallengeHandler)
+		telemetry.POST("/challenge", SubmitChallengeHandler)
+	}
 
-	// Challenge submission endpoint
-	router.POST("/api/v1/challenge", SubmitChallengeHandler)
-	log.Println("[Boot] ✅ POST /api/v1/challenge")
+	// Admin routes require a client certificate and/or API key per
+	// tlsConfig.Mode; unauthenticated access to the security log is how
+	// this surface used to leak.
+	admin := router.Group("/api/v1/admin")
+	admin.Use(AdminAuthMiddleware(tlsConfig))
+	{
+		admin.GET("/events", GetEventsHandler)
+		admin.GET("/events/stream", StreamEventsHandler)
+		admin.GET("/baseline/:userId", GetBaselineHandler)
+		admin.DELETE("/baseline/:userId", ResetBaselineHandler)
+	}
 
-	// Admin events endpoint
-	router.GET("/api/v1/admin/events", GetEventsHandler)
-	log.Println("[Boot] ✅ GET /api/v1/admin/events")
+	// Prometheus scrape endpoint
+	router.GET("/metrics", gin.WrapH(MetricsHandler()))
 
 	// Root endpoint
 	router.GET("/", func(c *gin.Context) {
@@ -485,23 +644,32 @@ func main() {
 			"status":  "running",
 		})
 	})
-	log.Println("[Boot] ✅ GET /")
 
 	// ==================== START SERVER ====================
 
 	port := ":3000"
-	log.Printf("\n[Boot] 🚀 Starting server on http://localhost:3000\n")
-	log.Printf("[Boot] Available endpoints:\n")
-	log.Printf("       GET  http://localhost:3000/\n")
-	log.Printf("       GET  http://localhost:3000/api/v1/health\n")
-	log.Printf("       POST http://localhost:3000/api/v1/verify\n")
-	log.Printf("       POST http://localhost:3000/api/v1/challenge\n")
-	log.Printf("       GET  http://localhost:3000/api/v1/admin/events\n")
-	log.Printf("\n[Boot] React frontend: http://localhost:5173\n")
-	log.Printf("[Boot] Admin dashboard: http://localhost:5173/admin\n")
-	log.Printf("[Boot] Press Ctrl+C to stop\n\n")
+	logger.Info("routes registered, starting server", "port", port)
+
+	if tlsConfig.Enabled {
+		httpsTLSConfig, err := tlsConfig.GetTLSConfig()
+		if err != nil {
+			log.Fatalf("[Boot] Failed to build TLS config: %v", err)
+		}
+
+		server := &http.Server{
+			Addr:      port,
+			Handler:   router,
+			TLSConfig: httpsTLSConfig,
+		}
+
+		logger.Info("TLS enabled")
+		if err := server.ListenAndServeTLS("", ""); err != nil {
+			log.Fatalf("[Boot] Failed to start HTTPS server: %v", err)
+		}
+		return
+	}
 
 	if err := router.Run(port); err != nil {
-		log.Fatalf("[Boot] ❌ Failed to start server: %v\n", err)
+		log.Fatalf("[Boot] Failed to start server: %v", err)
 	}
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// ==================== SLIDING-WINDOW RATE LIMITING ====================
+
+// maxChallengeFailures is how many failed challenge attempts a single key
+// (IP or userID) may accrue within challengeRateLimitWindow before being
+// temporarily blocked.
+const (
+	maxChallengeFailures   = 5
+	challengeRateLimitWindow = 10 * time.Minute
+)
+
+// RateLimiter tracks failed-attempt timestamps per key in a sliding
+// window, used to keep OTP brute-forcing from being free.
+type RateLimiter struct {
+	mu       sync.Mutex
+	failures map[string][]time.Time
+	max      int
+	window   time.Duration
+}
+
+// NewRateLimiter creates a rate limiter allowing up to max failures per
+// key within window.
+func NewRateLimiter(max int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		failures: make(map[string][]time.Time),
+		max:      max,
+		window:   window,
+	}
+}
+
+// Blocked reports whether key has already hit the failure limit within
+// the current window.
+func (r *RateLimiter) Blocked(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.prune(key, time.Now())) >= r.max
+}
+
+// RecordFailure adds a failure timestamp for key.
+func (r *RateLimiter) RecordFailure(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.failures[key] = append(r.prune(key, now), now)
+}
+
+// Reset clears a key's failure history, called after a successful
+// challenge so a legitimate user isn't penalized by earlier typos.
+func (r *RateLimiter) Reset(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.failures, key)
+}
+
+// prune drops timestamps older than the window and updates the map entry.
+// Callers must hold r.mu.
+func (r *RateLimiter) prune(key string, now time.Time) []time.Time {
+	cutoff := now.Add(-r.window)
+	kept := r.failures[key][:0]
+	for _, t := range r.failures[key] {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	r.failures[key] = kept
+	return kept
+}
+
+var challengeRateLimiter = NewRateLimiter(maxChallengeFailures, challengeRateLimitWindow)
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBlocksAfterMaxFailures(t *testing.T) {
+	rl := NewRateLimiter(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		rl.RecordFailure("ip-1")
+	}
+	if rl.Blocked("ip-1") {
+		t.Fatal("expected not blocked before hitting max failures")
+	}
+
+	rl.RecordFailure("ip-1")
+	if !rl.Blocked("ip-1") {
+		t.Fatal("expected blocked after hitting max failures")
+	}
+}
+
+func TestRateLimiterKeysAreIndependent(t *testing.T) {
+	rl := NewRateLimiter(1, time.Minute)
+
+	rl.RecordFailure("ip-1")
+	if !rl.Blocked("ip-1") {
+		t.Fatal("expected ip-1 to be blocked")
+	}
+	if rl.Blocked("ip-2") {
+		t.Fatal("expected ip-2 to be unaffected by ip-1's failures")
+	}
+}
+
+func TestRateLimiterReset(t *testing.T) {
+	rl := NewRateLimiter(1, time.Minute)
+
+	rl.RecordFailure("ip-1")
+	if !rl.Blocked("ip-1") {
+		t.Fatal("expected blocked after a failure")
+	}
+
+	rl.Reset("ip-1")
+	if rl.Blocked("ip-1") {
+		t.Fatal("expected unblocked after reset")
+	}
+}
+
+func TestRateLimiterPrunesOldFailures(t *testing.T) {
+	rl := NewRateLimiter(1, 10*time.Millisecond)
+
+	rl.RecordFailure("ip-1")
+	if !rl.Blocked("ip-1") {
+		t.Fatal("expected blocked immediately after a failure")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if rl.Blocked("ip-1") {
+		t.Fatal("expected failure to have aged out of the window")
+	}
+}
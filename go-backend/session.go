@@ -0,0 +1,260 @@
+package main
+
+import (
+	"container/list"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== SESSION KEYS & ANTI-REPLAY ====================
+//
+// Before this, a captured /verify request could simply be replayed -
+// nothing bound it to a single use. Now the client establishes a
+// per-session HMAC key up front via /session/init, and every /verify
+// request must carry a monotonically increasing nonce and a signature
+// over its own content, so a copied request is rejected by either the
+// nonce or the timestamp check.
+
+const (
+	// sessionTTL is how long a session key from /session/init stays valid.
+	sessionTTL = 30 * time.Minute
+
+	// requestTimestampSkew is the maximum allowed difference between a
+	// request's declared timestamp and the server's clock.
+	requestTimestampSkew = 30 * time.Second
+
+	// nonceCacheSize bounds how many recent nonces are remembered per
+	// user; a replay older than this many requests is allowed to age out
+	// since the nonce is expected to keep climbing anyway.
+	nonceCacheSize = 256
+)
+
+// UserSession is the HMAC key handed out to a single client at
+// /session/init, used to sign its subsequent /verify requests.
+type UserSession struct {
+	UserID    string
+	Key       []byte
+	ExpiresAt int64
+}
+
+// nonceCache remembers the most recent nonces seen for one user so a
+// replayed request (same nonce resubmitted) is rejected even if its
+// timestamp is still within skew.
+type nonceCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	index    map[int64]*list.Element
+}
+
+func newNonceCache(capacity int) *nonceCache {
+	return &nonceCache{
+		capacity: capacity,
+		order:    list.New(),
+		index:    make(map[int64]*list.Element),
+	}
+}
+
+// seenOrRecord reports whether nonce has already been recorded; if not,
+// it records it and evicts the oldest entry once the cache is full.
+func (c *nonceCache) seenOrRecord(nonce int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.index[nonce]; ok {
+		return true
+	}
+
+	c.index[nonce] = c.order.PushFront(nonce)
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if oldest != nil {
+			c.order.Remove(oldest)
+			delete(c.index, oldest.Value.(int64))
+		}
+	}
+	return false
+}
+
+// SessionStore issues and validates per-user session keys and tracks the
+// nonces spent against them.
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*UserSession
+	nonces   map[string]*nonceCache
+}
+
+// NewSessionStore creates an empty in-memory session store.
+func NewSessionStore() *SessionStore {
+	return &SessionStore{
+		sessions: make(map[string]*UserSession),
+		nonces:   make(map[string]*nonceCache),
+	}
+}
+
+// Init generates a fresh random session key for userID, replacing any
+// previous one, and returns it.
+func (s *SessionStore) Init(userID string) (*UserSession, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate session key: %w", err)
+	}
+
+	session := &UserSession{
+		UserID:    userID,
+		Key:       key,
+		ExpiresAt: time.Now().Add(sessionTTL).Unix(),
+	}
+
+	s.mu.Lock()
+	s.sessions[userID] = session
+	s.mu.Unlock()
+
+	return session, nil
+}
+
+// nonceCacheFor returns (creating if necessary) the nonce cache for userID.
+func (s *SessionStore) nonceCacheFor(userID string) *nonceCache {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nc, ok := s.nonces[userID]
+	if !ok {
+		nc = newNonceCache(nonceCacheSize)
+		s.nonces[userID] = nc
+	}
+	return nc
+}
+
+// ValidateRequest checks a /verify request's anti-replay envelope: a
+// live session must exist for userID, timestamp must be within
+// requestTimestampSkew of the server's clock, nonce must not have been
+// seen before, and signature must equal
+// hex(HMAC-SHA256(sessionKey, userID||nonce||telemetryHash||timestamp)).
+func (s *SessionStore) ValidateRequest(userID string, nonce int64, telemetryHash string, timestamp int64, signature string) error {
+	s.mu.Lock()
+	session, ok := s.sessions[userID]
+	s.mu.Unlock()
+	if !ok {
+		return errors.New("no active session, call /session/init first")
+	}
+	if time.Now().Unix() > session.ExpiresAt {
+		return errors.New("session expired, call /session/init again")
+	}
+
+	// Computed in float64 rather than int64 so an out-of-range attacker-
+	// supplied timestamp (e.g. near math.MinInt64) can't wrap the
+	// subtraction around to a small value and sneak past the check.
+	skew := math.Abs(float64(time.Now().Unix()) - float64(timestamp))
+	if skew > requestTimestampSkew.Seconds() {
+		return fmt.Errorf("timestamp skew too large: %.0fs", skew)
+	}
+
+	mac := hmac.New(sha256.New, session.Key)
+	mac.Write([]byte(userID))
+	mac.Write([]byte(strconv.FormatInt(nonce, 10)))
+	mac.Write([]byte(telemetryHash))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(expected)) != 1 {
+		return errors.New("invalid signature")
+	}
+
+	// Only spend the nonce once the signature proves the caller actually
+	// holds the session key. Recording it earlier let anyone who merely
+	// knew the API key (the widget-facing secret every browser embeds)
+	// pre-burn a victim's next nonce with a garbage signature, so the
+	// victim's real, correctly-signed request would later be rejected as
+	// an already-used nonce - a replay-protection DoS with no forged key
+	// required.
+	if s.nonceCacheFor(userID).seenOrRecord(nonce) {
+		return errors.New("nonce already used")
+	}
+	return nil
+}
+
+var sessionStore = NewSessionStore()
+
+// hashTelemetry returns the hex-encoded SHA-256 hash of a telemetry
+// payload's JSON encoding, used as the telemetryHash component the
+// client signs over so the signature covers what was actually submitted.
+func hashTelemetry(t TelemetryData) (string, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ==================== SESSION HANDLERS ====================
+
+// SessionInitRequest asks the server to mint a fresh session key for a user.
+type SessionInitRequest struct {
+	UserID string `json:"userId" binding:"required"`
+}
+
+// SessionInitResponse hands back the session key and its expiry. The
+// client uses SessionKey to sign subsequent /verify requests.
+type SessionInitResponse struct {
+	SessionKey string `json:"sessionKey"`
+	ExpiresAt  int64  `json:"expiresAt"`
+}
+
+// sessionInitRateLimiter throttles how often a session key can be
+// (re)minted per caller IP, on top of the issuer-auth gate in front of
+// this handler.
+var sessionInitRateLimiter = NewRateLimiter(10, time.Minute)
+
+// SessionInitHandler establishes a per-session HMAC key for a user,
+// which the client must use to sign its /verify requests. Unlike /verify
+// and /challenge, this route sits behind SessionIssuerHMACSecret rather
+// than the widget's own APIKeyHMACSecret (see TLSConfig) - it's called
+// server-to-server by the integrating site's backend once it has
+// authenticated the user itself, which relays the minted key to that
+// user's browser. That's what ties "who this session is for" to
+// something the end user/attacker can't forge: a secret that never
+// ships to a browser, rather than a userId string the caller supplies.
+func SessionInitHandler(c *gin.Context) {
+	var req SessionInitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	ip := c.ClientIP()
+	if sessionInitRateLimiter.Blocked(ip) {
+		logger.Warn("blocked session init: too many requests", "ip", ip)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many session requests, try again later"})
+		return
+	}
+	sessionInitRateLimiter.RecordFailure(ip)
+
+	session, err := sessionStore.Init(req.UserID)
+	if err != nil {
+		logger.Error("failed to init session", "userId", req.UserID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize session"})
+		return
+	}
+
+	logger.Debug("session initialized", "userId", req.UserID, "expiresAt", session.ExpiresAt)
+
+	c.JSON(http.StatusOK, SessionInitResponse{
+		SessionKey: hex.EncodeToString(session.Key),
+		ExpiresAt:  session.ExpiresAt,
+	})
+}
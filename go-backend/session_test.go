@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signRequest(key []byte, userID string, nonce int64, telemetryHash string, timestamp int64) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(userID))
+	mac.Write([]byte(strconv.FormatInt(nonce, 10)))
+	mac.Write([]byte(telemetryHash))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSessionStoreValidateRequestAcceptsCorrectSignature(t *testing.T) {
+	s := NewSessionStore()
+	session, err := s.Init("user-1")
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	now := time.Now().Unix()
+	sig := signRequest(session.Key, "user-1", 1, "hash", now)
+
+	if err := s.ValidateRequest("user-1", 1, "hash", now, sig); err != nil {
+		t.Fatalf("expected valid request to be accepted, got %v", err)
+	}
+}
+
+func TestSessionStoreValidateRequestRejectsBadSignature(t *testing.T) {
+	s := NewSessionStore()
+	if _, err := s.Init("user-1"); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	now := time.Now().Unix()
+	if err := s.ValidateRequest("user-1", 1, "hash", now, "deadbeef"); err == nil {
+		t.Fatal("expected an invalid signature to be rejected")
+	}
+}
+
+func TestSessionStoreValidateRequestRejectsReplayedNonce(t *testing.T) {
+	s := NewSessionStore()
+	session, err := s.Init("user-1")
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	now := time.Now().Unix()
+	sig := signRequest(session.Key, "user-1", 1, "hash", now)
+
+	if err := s.ValidateRequest("user-1", 1, "hash", now, sig); err != nil {
+		t.Fatalf("expected first use to be accepted, got %v", err)
+	}
+	if err := s.ValidateRequest("user-1", 1, "hash", now, sig); err == nil {
+		t.Fatal("expected replayed nonce to be rejected")
+	}
+}
+
+func TestSessionStoreValidateRequestRejectsStaleTimestamp(t *testing.T) {
+	s := NewSessionStore()
+	session, err := s.Init("user-1")
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	stale := time.Now().Add(-2 * requestTimestampSkew).Unix()
+	sig := signRequest(session.Key, "user-1", 1, "hash", stale)
+
+	if err := s.ValidateRequest("user-1", 1, "hash", stale, sig); err == nil {
+		t.Fatal("expected a stale timestamp to be rejected")
+	}
+}
+
+// TestSessionStoreValidateRequestBadSignatureDoesNotBurnNonce guards
+// against a replay-protection DoS: a request with a bad signature must
+// not spend the nonce, or an attacker who only knows the widget-facing
+// API key (not the victim's session key) could pre-burn the victim's
+// next nonce and get their real, correctly-signed request rejected as
+// "nonce already used".
+func TestSessionStoreValidateRequestBadSignatureDoesNotBurnNonce(t *testing.T) {
+	s := NewSessionStore()
+	session, err := s.Init("user-1")
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	now := time.Now().Unix()
+	if err := s.ValidateRequest("user-1", 1, "hash", now, "deadbeef"); err == nil {
+		t.Fatal("expected the bad signature to be rejected")
+	}
+
+	sig := signRequest(session.Key, "user-1", 1, "hash", now)
+	if err := s.ValidateRequest("user-1", 1, "hash", now, sig); err != nil {
+		t.Fatalf("expected the real request to still be able to use nonce 1, got %v", err)
+	}
+}
+
+func TestSessionStoreValidateRequestRejectsUnknownUser(t *testing.T) {
+	s := NewSessionStore()
+	if err := s.ValidateRequest("never-initialized", 1, "hash", time.Now().Unix(), "anything"); err == nil {
+		t.Fatal("expected a request with no active session to be rejected")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ==================== PERSISTENT STORAGE ====================
+
+// ChallengeState is a pending OTP challenge bound to a user and request,
+// persisted so it survives restarts and is visible across instances.
+type ChallengeState struct {
+	UserID    string `json:"userId"`
+	RequestID string `json:"requestId"`
+	Code      string `json:"code"`
+	ExpiresAt int64  `json:"expiresAt"`
+	Consumed  bool   `json:"consumed"`
+}
+
+// Store is the persistence boundary for everything that used to live only
+// in process memory: the security event log, per-user behavioral
+// baselines, and outstanding OTP challenges. Implementations back it with
+// SQLite (default, single-instance) or etcd/Postgres (multi-instance).
+type Store interface {
+	SaveEvent(event SecurityEvent) error
+	ListEvents(userID string, since int64, limit int) ([]SecurityEvent, error)
+
+	SaveBaseline(baseline *UserBaseline) error
+	LoadBaseline(userID string) (*UserBaseline, error)
+
+	SaveChallenge(challenge *ChallengeState) error
+	// ConsumeChallenge atomically verifies code against the stored
+	// challenge and marks it consumed only if it matches and hasn't
+	// expired, returning an error otherwise (not found, already
+	// consumed, expired, or wrong code). A mistyped code therefore
+	// leaves the challenge available for a retry instead of burning it.
+	ConsumeChallenge(userID, requestID, code string) (*ChallengeState, error)
+
+	Close() error
+}
+
+// StoreConfig selects and configures the storage backend at boot.
+type StoreConfig struct {
+	Backend  string `yaml:"backend"` // "sqlite", "postgres", or "etcd"
+	SQLite   struct {
+		Path string `yaml:"path"`
+	} `yaml:"sqlite"`
+	Postgres struct {
+		DSN string `yaml:"dsn"`
+	} `yaml:"postgres"`
+	Etcd struct {
+		Endpoints []string `yaml:"endpoints"`
+	} `yaml:"etcd"`
+}
+
+// DefaultStoreConfig is used when no config file is present, keeping
+// local/dev setups zero-config.
+var DefaultStoreConfig = StoreConfig{
+	Backend: "sqlite",
+}
+
+// LoadStoreConfig reads the storage backend configuration from a YAML
+// file, falling back to DefaultStoreConfig if it's missing or malformed.
+func LoadStoreConfig(path string) StoreConfig {
+	cfg := DefaultStoreConfig
+	cfg.SQLite.Path = "neurogate.db"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Warn("no store config, defaulting to SQLite", "path", path, "sqlitePath", cfg.SQLite.Path)
+		return cfg
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		logger.Warn("failed to parse store config, defaulting to SQLite", "path", path, "error", err)
+		return DefaultStoreConfig
+	}
+
+	return cfg
+}
+
+// NewStore constructs the Store implementation selected by cfg.Backend.
+func NewStore(cfg StoreConfig) (Store, error) {
+	switch cfg.Backend {
+	case "", "sqlite":
+		return NewSQLiteStore(cfg.SQLite.Path)
+	case "postgres":
+		return NewPostgresStore(cfg.Postgres.DSN)
+	case "etcd":
+		return NewEtcdStore(cfg.Etcd.Endpoints)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// EtcdStore is a Store implementation backed by etcd, useful for
+// multi-instance deployments that already run etcd for coordination and
+// would rather not stand up a separate Postgres instance.
+type EtcdStore struct {
+	client *clientv3.Client
+}
+
+const (
+	etcdEventPrefix     = "/neurogate/events/"
+	etcdBaselinePrefix  = "/neurogate/baselines/"
+	etcdChallengePrefix = "/neurogate/challenges/"
+	etcdRequestTimeout  = 5 * time.Second
+
+	// etcdEventScanCap bounds how many event keys ListEvents will ever
+	// fetch in one call. userID isn't part of the event key, so a
+	// userID-filtered query can't be satisfied by range bounds alone;
+	// this cap keeps that case from degenerating into a full-prefix scan.
+	etcdEventScanCap = 5000
+)
+
+// NewEtcdStore connects to the given etcd endpoints.
+func NewEtcdStore(endpoints []string) (*EtcdStore, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("etcd store requires at least one endpoint")
+	}
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connect to etcd: %w", err)
+	}
+
+	return &EtcdStore{client: client}, nil
+}
+
+// SaveEvent persists a security event under a key ordered by timestamp so
+// ListEvents can range-scan without a secondary index.
+func (e *EtcdStore) SaveEvent(event SecurityEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s%020d-%s", etcdEventPrefix, event.Timestamp, event.ID)
+	_, err = e.client.Put(ctx, key, string(data))
+	return err
+}
+
+// ListEvents range-scans events from since onward and returns the
+// newest `limit` matches. Event keys are "<prefix><zero-padded
+// timestamp>-<id>", so the since bound and a descending key sort push
+// both the time filter and the ordering down to etcd instead of
+// pulling the whole event history into memory. userID isn't part of
+// the key, so a userID filter still requires scanning client-side, but
+// etcdEventScanCap keeps that scan bounded rather than unbounded.
+func (e *EtcdStore) ListEvents(userID string, since int64, limit int) ([]SecurityEvent, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	startKey := fmt.Sprintf("%s%020d", etcdEventPrefix, since)
+	endKey := clientv3.GetPrefixRangeEnd(etcdEventPrefix)
+
+	fetchLimit := int64(limit)
+	if userID != "" && fetchLimit < etcdEventScanCap {
+		fetchLimit = etcdEventScanCap
+	}
+
+	resp, err := e.client.Get(ctx, startKey,
+		clientv3.WithRange(endKey),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend),
+		clientv3.WithLimit(fetchLimit),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]SecurityEvent, 0, limit)
+	for _, kv := range resp.Kvs {
+		var ev SecurityEvent
+		if err := json.Unmarshal(kv.Value, &ev); err != nil {
+			continue
+		}
+		if userID != "" && ev.UserID != userID {
+			continue
+		}
+		events = append(events, ev)
+		if len(events) >= limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+// SaveBaseline upserts a user's behavioral baseline.
+func (e *EtcdStore) SaveBaseline(baseline *UserBaseline) error {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.client.Put(ctx, etcdBaselinePrefix+baseline.UserID, string(data))
+	return err
+}
+
+// LoadBaseline loads a user's persisted baseline, or nil if none exists yet.
+func (e *EtcdStore) LoadBaseline(userID string) (*UserBaseline, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	resp, err := e.client.Get(ctx, etcdBaselinePrefix+userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var baseline UserBaseline
+	if err := json.Unmarshal(resp.Kvs[0].Value, &baseline); err != nil {
+		return nil, err
+	}
+	return &baseline, nil
+}
+
+// SaveChallenge persists a freshly issued OTP challenge.
+func (e *EtcdStore) SaveChallenge(challenge *ChallengeState) error {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return err
+	}
+
+	key := etcdChallengePrefix + challenge.UserID + "/" + challenge.RequestID
+	_, err = e.client.Put(ctx, key, string(data))
+	return err
+}
+
+// ConsumeChallenge uses an etcd transaction so the load-verify-mark-
+// consumed step is atomic across instances, and only marks the
+// challenge consumed once code actually verifies - a mistyped code
+// leaves it intact for a retry instead of burning it.
+func (e *EtcdStore) ConsumeChallenge(userID, requestID, code string) (*ChallengeState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	key := etcdChallengePrefix + userID + "/" + requestID
+
+	resp, err := e.client.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, fmt.Errorf("no challenge found for user %s, request %s", userID, requestID)
+	}
+
+	var ch ChallengeState
+	if err := json.Unmarshal(resp.Kvs[0].Value, &ch); err != nil {
+		return nil, err
+	}
+	if ch.Consumed {
+		return &ch, fmt.Errorf("challenge for user %s, request %s already consumed", userID, requestID)
+	}
+	if time.Now().Unix() > ch.ExpiresAt {
+		return &ch, fmt.Errorf("challenge for user %s, request %s expired", userID, requestID)
+	}
+	if !verifyCode(code, ch.Code) {
+		return &ch, fmt.Errorf("invalid code for user %s, request %s", userID, requestID)
+	}
+
+	ch.Consumed = true
+	data, err := json.Marshal(ch)
+	if err != nil {
+		return nil, err
+	}
+
+	txnResp, err := e.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision)).
+		Then(clientv3.OpPut(key, string(data))).
+		Commit()
+	if err != nil {
+		return nil, err
+	}
+	if !txnResp.Succeeded {
+		return nil, fmt.Errorf("challenge for user %s, request %s was modified concurrently", userID, requestID)
+	}
+
+	return &ch, nil
+}
+
+// Close releases the underlying etcd client connection.
+func (e *EtcdStore) Close() error {
+	return e.client.Close()
+}
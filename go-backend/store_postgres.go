@@ -0,0 +1,209 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// PostgresStore is a Store implementation backed by Postgres, intended
+// for multi-instance deployments where every instance needs to see the
+// same events, baselines, and challenges.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to Postgres using dsn and ensures the schema exists.
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("postgres store requires a dsn")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres store: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("connect to postgres: %w", err)
+	}
+
+	store := &PostgresStore{db: db}
+	if err := store.migrate(); err != nil {
+		return nil, fmt.Errorf("migrate postgres store: %w", err)
+	}
+	return store, nil
+}
+
+func (p *PostgresStore) migrate() error {
+	_, err := p.db.Exec(`
+		CREATE TABLE IF NOT EXISTS events (
+			id TEXT PRIMARY KEY,
+			timestamp BIGINT NOT NULL,
+			user_id TEXT NOT NULL,
+			ip TEXT NOT NULL,
+			trust_score DOUBLE PRECISION NOT NULL,
+			status TEXT NOT NULL,
+			time TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_events_user_time ON events(user_id, timestamp);
+
+		CREATE TABLE IF NOT EXISTS baselines (
+			user_id TEXT PRIMARY KEY,
+			data JSONB NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS challenges (
+			user_id TEXT NOT NULL,
+			request_id TEXT NOT NULL,
+			code TEXT NOT NULL,
+			expires_at BIGINT NOT NULL,
+			consumed BOOLEAN NOT NULL DEFAULT FALSE,
+			PRIMARY KEY (user_id, request_id)
+		);
+	`)
+	return err
+}
+
+// SaveEvent persists a security event.
+func (p *PostgresStore) SaveEvent(event SecurityEvent) error {
+	_, err := p.db.Exec(
+		`INSERT INTO events (id, timestamp, user_id, ip, trust_score, status, time)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 ON CONFLICT (id) DO UPDATE SET trust_score = excluded.trust_score, status = excluded.status`,
+		event.ID, event.Timestamp, event.UserID, event.IP, event.TrustScore, event.Status, event.Time,
+	)
+	return err
+}
+
+// ListEvents returns events newer than since, optionally filtered by
+// user, newest first, capped at limit rows.
+func (p *PostgresStore) ListEvents(userID string, since int64, limit int) ([]SecurityEvent, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `SELECT id, timestamp, user_id, ip, trust_score, status, time FROM events WHERE timestamp >= $1`
+	args := []interface{}{since}
+
+	if userID != "" {
+		query += ` AND user_id = $2 ORDER BY timestamp DESC LIMIT $3`
+		args = append(args, userID, limit)
+	} else {
+		query += ` ORDER BY timestamp DESC LIMIT $2`
+		args = append(args, limit)
+	}
+
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []SecurityEvent
+	for rows.Next() {
+		var e SecurityEvent
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.UserID, &e.IP, &e.TrustScore, &e.Status, &e.Time); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// SaveBaseline upserts a user's behavioral baseline.
+func (p *PostgresStore) SaveBaseline(baseline *UserBaseline) error {
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.Exec(
+		`INSERT INTO baselines (user_id, data) VALUES ($1, $2)
+		 ON CONFLICT (user_id) DO UPDATE SET data = excluded.data`,
+		baseline.UserID, data,
+	)
+	return err
+}
+
+// LoadBaseline loads a user's persisted baseline, or nil if none exists yet.
+func (p *PostgresStore) LoadBaseline(userID string) (*UserBaseline, error) {
+	var data []byte
+	err := p.db.QueryRow(`SELECT data FROM baselines WHERE user_id = $1`, userID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline UserBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, err
+	}
+	return &baseline, nil
+}
+
+// SaveChallenge persists a freshly issued OTP challenge.
+func (p *PostgresStore) SaveChallenge(challenge *ChallengeState) error {
+	_, err := p.db.Exec(
+		`INSERT INTO challenges (user_id, request_id, code, expires_at, consumed)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (user_id, request_id) DO UPDATE SET code = excluded.code, expires_at = excluded.expires_at, consumed = excluded.consumed`,
+		challenge.UserID, challenge.RequestID, challenge.Code, challenge.ExpiresAt, challenge.Consumed,
+	)
+	return err
+}
+
+// ConsumeChallenge locks the challenge row, verifies code against it,
+// and marks it consumed in the same transaction - but only once it
+// actually verifies, so a mistyped code doesn't burn the challenge
+// before the caller gets a chance to retry.
+func (p *PostgresStore) ConsumeChallenge(userID, requestID, code string) (*ChallengeState, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var ch ChallengeState
+	err = tx.QueryRow(
+		`SELECT user_id, request_id, code, expires_at, consumed FROM challenges
+		 WHERE user_id = $1 AND request_id = $2 FOR UPDATE`,
+		userID, requestID,
+	).Scan(&ch.UserID, &ch.RequestID, &ch.Code, &ch.ExpiresAt, &ch.Consumed)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no challenge found for user %s, request %s", userID, requestID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if ch.Consumed {
+		return &ch, fmt.Errorf("challenge for user %s, request %s already consumed", userID, requestID)
+	}
+
+	if time.Now().Unix() > ch.ExpiresAt {
+		return &ch, fmt.Errorf("challenge for user %s, request %s expired", userID, requestID)
+	}
+
+	if !verifyCode(code, ch.Code) {
+		return &ch, fmt.Errorf("invalid code for user %s, request %s", userID, requestID)
+	}
+
+	if _, err := tx.Exec(`UPDATE challenges SET consumed = TRUE WHERE user_id = $1 AND request_id = $2`, userID, requestID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &ch, nil
+}
+
+// Close releases the underlying connection pool.
+func (p *PostgresStore) Close() error {
+	return p.db.Close()
+}
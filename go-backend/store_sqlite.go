@@ -0,0 +1,206 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteStore is the default, file-backed Store implementation. It's the
+// right choice for a single-instance deployment; multi-instance setups
+// should use Postgres or etcd instead so instances share state.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (and migrates) a SQLite-backed store at path.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if path == "" {
+		path = "neurogate.db"
+	}
+
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite store: %w", err)
+	}
+
+	store := &SQLiteStore{db: db}
+	if err := store.migrate(); err != nil {
+		return nil, fmt.Errorf("migrate sqlite store: %w", err)
+	}
+
+	return store, nil
+}
+
+func (s *SQLiteStore) migrate() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS events (
+			id TEXT PRIMARY KEY,
+			timestamp INTEGER NOT NULL,
+			user_id TEXT NOT NULL,
+			ip TEXT NOT NULL,
+			trust_score REAL NOT NULL,
+			status TEXT NOT NULL,
+			time TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_events_user_time ON events(user_id, timestamp);
+
+		CREATE TABLE IF NOT EXISTS baselines (
+			user_id TEXT PRIMARY KEY,
+			data TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS challenges (
+			user_id TEXT NOT NULL,
+			request_id TEXT NOT NULL,
+			code TEXT NOT NULL,
+			expires_at INTEGER NOT NULL,
+			consumed INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (user_id, request_id)
+		);
+	`)
+	return err
+}
+
+// SaveEvent persists a security event.
+func (s *SQLiteStore) SaveEvent(event SecurityEvent) error {
+	_, err := s.db.Exec(
+		`INSERT OR REPLACE INTO events (id, timestamp, user_id, ip, trust_score, status, time)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		event.ID, event.Timestamp, event.UserID, event.IP, event.TrustScore, event.Status, event.Time,
+	)
+	return err
+}
+
+// ListEvents returns events newer than since (unix seconds; 0 means no
+// lower bound), optionally filtered to a single user, newest first,
+// capped at limit rows.
+func (s *SQLiteStore) ListEvents(userID string, since int64, limit int) ([]SecurityEvent, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `SELECT id, timestamp, user_id, ip, trust_score, status, time FROM events WHERE timestamp >= ?`
+	args := []interface{}{since}
+
+	if userID != "" {
+		query += ` AND user_id = ?`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY timestamp DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []SecurityEvent
+	for rows.Next() {
+		var e SecurityEvent
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.UserID, &e.IP, &e.TrustScore, &e.Status, &e.Time); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// SaveBaseline upserts a user's behavioral baseline as a JSON blob.
+func (s *SQLiteStore) SaveBaseline(baseline *UserBaseline) error {
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(
+		`INSERT INTO baselines (user_id, data) VALUES (?, ?)
+		 ON CONFLICT(user_id) DO UPDATE SET data = excluded.data`,
+		baseline.UserID, string(data),
+	)
+	return err
+}
+
+// LoadBaseline loads a user's persisted baseline, or nil if none exists yet.
+func (s *SQLiteStore) LoadBaseline(userID string) (*UserBaseline, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM baselines WHERE user_id = ?`, userID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline UserBaseline
+	if err := json.Unmarshal([]byte(data), &baseline); err != nil {
+		return nil, err
+	}
+	return &baseline, nil
+}
+
+// SaveChallenge persists a freshly issued OTP challenge.
+func (s *SQLiteStore) SaveChallenge(challenge *ChallengeState) error {
+	_, err := s.db.Exec(
+		`INSERT OR REPLACE INTO challenges (user_id, request_id, code, expires_at, consumed)
+		 VALUES (?, ?, ?, ?, ?)`,
+		challenge.UserID, challenge.RequestID, challenge.Code, challenge.ExpiresAt, challenge.Consumed,
+	)
+	return err
+}
+
+// ConsumeChallenge loads a challenge, verifies code against it, and
+// marks it consumed in the same transaction - but only once it actually
+// verifies, so a mistyped code doesn't burn the challenge before the
+// caller gets a chance to retry.
+func (s *SQLiteStore) ConsumeChallenge(userID, requestID, code string) (*ChallengeState, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var ch ChallengeState
+	var consumed int
+	err = tx.QueryRow(
+		`SELECT user_id, request_id, code, expires_at, consumed FROM challenges WHERE user_id = ? AND request_id = ?`,
+		userID, requestID,
+	).Scan(&ch.UserID, &ch.RequestID, &ch.Code, &ch.ExpiresAt, &consumed)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no challenge found for user %s, request %s", userID, requestID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ch.Consumed = consumed != 0
+
+	if ch.Consumed {
+		return &ch, fmt.Errorf("challenge for user %s, request %s already consumed", userID, requestID)
+	}
+
+	if time.Now().Unix() > ch.ExpiresAt {
+		return &ch, fmt.Errorf("challenge for user %s, request %s expired", userID, requestID)
+	}
+
+	if !verifyCode(code, ch.Code) {
+		return &ch, fmt.Errorf("invalid code for user %s, request %s", userID, requestID)
+	}
+
+	if _, err := tx.Exec(`UPDATE challenges SET consumed = 1 WHERE user_id = ? AND request_id = ?`, userID, requestID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &ch, nil
+}
+
+// Close releases the underlying database handle.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ==================== TELEMETRY ====================
+//
+// This replaces the ad-hoc log.Printf diagnostics that used to live
+// inline in CalculateRisk with: Prometheus metrics for dashboards/alerts,
+// a structured JSON event per verification for offline analysis, and a
+// leveled logger so production runs aren't drowned in emoji.
+
+var (
+	trustScoreGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "neurogate_trust_score",
+		Help: "Trust score of the most recently evaluated verification (0-100).",
+	})
+
+	entropyScoreHistogram = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "neurogate_entropy_score",
+		Help:    "Distribution of mouse-path entropy scores.",
+		Buckets: prometheus.LinearBuckets(0, 10, 10),
+	})
+
+	keystrokeVarianceHistogram = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "neurogate_keystroke_variance",
+		Help:    "Distribution of keystroke flight-time variance.",
+		Buckets: prometheus.ExponentialBuckets(10, 2, 10),
+	})
+
+	challengesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "neurogate_challenges_total",
+		Help: "Count of challenge outcomes by status.",
+	}, []string{"status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "neurogate_request_duration_seconds",
+		Help:    "Request latency by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	entropyMismatchTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "neurogate_entropy_mismatch_total",
+		Help: "Count of verify requests whose client-declared entropy score disagreed with the server-computed one beyond tolerance.",
+	})
+)
+
+// logger is the process-wide leveled logger. Defaults to Info level;
+// InitLogger switches it to Debug when the debug flag is set.
+var logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+// InitLogger (re)configures the package-level logger's level based on debug.
+func InitLogger(debug bool) {
+	level := slog.LevelInfo
+	if debug {
+		level = slog.LevelDebug
+	}
+	logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+}
+
+// VerificationEvent is the structured record emitted for every /verify
+// call, capturing the per-feature contributions behind the final score
+// so an analyst can reconstruct why a request was scored the way it was.
+type VerificationEvent struct {
+	UserID            string  `json:"userId"`
+	Timestamp         int64   `json:"timestamp"`
+	TrustScore        float64 `json:"trustScore"`
+	EntropyScore      float64 `json:"entropyScore"`
+	FlightVariance    float64 `json:"flightVariance"`
+	DwellVariance     float64 `json:"dwellVariance"`
+	MouseAcceleration float64 `json:"mouseAcceleration"`
+	BaselineWarm      bool    `json:"baselineWarm"`
+	RequiresChallenge bool    `json:"requiresChallenge"`
+}
+
+// RecordVerification updates the Prometheus metrics for a verification
+// and emits the event as a structured JSON log line.
+func RecordVerification(event VerificationEvent) {
+	trustScoreGauge.Set(event.TrustScore)
+	entropyScoreHistogram.Observe(event.EntropyScore)
+	if event.FlightVariance > 0 {
+		keystrokeVarianceHistogram.Observe(event.FlightVariance)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("failed to marshal verification event", "error", err)
+		return
+	}
+	logger.Info("verification", "event", string(data))
+}
+
+// RecordChallengeOutcome increments the challenges counter for status
+// ("issued", "accepted", "rejected", or "blocked").
+func RecordChallengeOutcome(status string) {
+	challengesTotal.WithLabelValues(status).Inc()
+}
+
+// RequestDurationMiddleware observes neurogate_request_duration_seconds
+// for every request that passes through it.
+func RequestDurationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		requestDuration.WithLabelValues(c.FullPath(), c.Request.Method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// MetricsHandler exposes Prometheus metrics for scraping at GET /metrics.
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ==================== LIVE EVENT STREAM (SSE) ====================
+
+// eventStream fans out security events to any admin dashboard connected
+// to GET /api/v1/admin/events/stream, so it can watch live instead of
+// polling GET /api/v1/admin/events.
+type eventStream struct {
+	mu      sync.Mutex
+	clients map[chan SecurityEvent]bool
+}
+
+var liveEvents = &eventStream{clients: make(map[chan SecurityEvent]bool)}
+
+// subscribe registers a new client channel and returns an unsubscribe func.
+func (s *eventStream) subscribe() (chan SecurityEvent, func()) {
+	ch := make(chan SecurityEvent, 16)
+
+	s.mu.Lock()
+	s.clients[ch] = true
+	s.mu.Unlock()
+
+	return ch, func() {
+		s.mu.Lock()
+		delete(s.clients, ch)
+		close(ch)
+		s.mu.Unlock()
+	}
+}
+
+// publish fans event out to every connected client, dropping it for any
+// client whose buffer is full rather than blocking the request path.
+func (s *eventStream) publish(event SecurityEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.clients {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// StreamEventsHandler serves GET /api/v1/admin/events/stream as
+// server-sent events: one `data: <json>` line per new security event.
+func StreamEventsHandler(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	ch, unsubscribe := liveEvents.subscribe()
+	defer unsubscribe()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return false
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				return true
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}